@@ -6,12 +6,17 @@ import (
 	"fmt"
 	"gateway/entity"
 	scheduleproto "gateway/proto/golang/schedule"
+	"gateway/tool/concurrency"
 	agenterrors "gateway/tool/consul/agent/errors"
+	"gateway/tool/idempotency"
 	jwtutil "gateway/tool/jwt"
+	"gateway/tool/outbox"
+	"gateway/tool/rpcerror"
 	code "gateway/utils/code/golang"
 	topic "gateway/utils/topic/golang"
 	"github.com/eapache/go-resiliency/breaker"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/micro/go-micro/v2/client"
 	"github.com/micro/go-micro/v2/errors"
 	"github.com/micro/go-micro/v2/metadata"
@@ -20,9 +25,39 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/uber/jaeger-client-go"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+// createScheduleOutcome is the terminal result of attempting to create a
+// schedule downstream. It is computed once per (uuid, endpoint,
+// Idempotency-Key) and is what gets cached and replayed on retry, since a
+// coalesced caller has its own *gin.Context and must render the response
+// itself rather than sharing the context that produced it.
+type createScheduleOutcome struct {
+	Status     int
+	Code       int
+	Message    string
+	TrackingID string
+	// Retryable mirrors rpcerror.GatewayError.Retryable so the
+	// Idempotency-Key cache knows not to pin a transient failure in place of
+	// a later, possibly successful, retry.
+	Retryable bool
+}
+
+func (o createScheduleOutcome) body() gin.H {
+	resp := gin.H{"status": o.Status, "code": o.Code, "message": o.Message}
+	switch o.Status {
+	case http.StatusCreated:
+		resp["schedule_uuid"] = ""
+	case http.StatusAccepted:
+		resp["tracking_uuid"] = o.TrackingID
+	}
+	return resp
+}
+
+const createScheduleEndpoint = "CreateSchedule"
+
 func (h *_default) CreateSchedule(c *gin.Context) {
 	reqID := c.GetHeader("X-Request-Id")
 	topSpan := h.tracer.StartSpan(fmt.Sprintf("%s %s", c.Request.Method, c.FullPath())).SetTag("X-Request-Id", reqID)
@@ -50,6 +85,18 @@ func (h *_default) CreateSchedule(c *gin.Context) {
 		return
 	}
 
+	// logic handling RateLimitExceeded
+	if decision := h.createScheduleLimiter.Allow(uuidClaims.UUID, c.ClientIP()); !decision.Allowed {
+		status, _code := http.StatusTooManyRequests, code.RateLimitExceeded
+		msg := "rate limit exceeded for CreateSchedule"
+		c.Header("Retry-After", strconv.Itoa(int(decision.RetryAfter.Seconds())))
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
+		topSpan.SetTag("status", status).SetTag("code", _code).SetTag("rate_limited", true).Finish()
+		return
+	}
+
 	// logic handling BadRequest
 	var receivedReq entity.CreateScheduleRequest
 	if ok, _code, msg := h.checkIfValidRequest(c, &receivedReq); ok {
@@ -63,6 +110,95 @@ func (h *_default) CreateSchedule(c *gin.Context) {
 	}
 	reqBytes, _ := json.Marshal(receivedReq)
 
+	// logic handling Idempotency-Key replay / concurrent coalescing. This
+	// runs ahead of consul node selection and the breaker so a retried or
+	// duplicated request never counts against either.
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey != "" {
+		fingerprint, fpErr := idempotency.Fingerprint(receivedReq)
+		if fpErr != nil {
+			status, _code := http.StatusInternalServerError, 0
+			msg := fmt.Sprintf("unable to compute idempotency fingerprint, err: %s", fpErr.Error())
+			c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+			entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+			topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
+			topSpan.SetTag("status", status).SetTag("code", _code).Finish()
+			return
+		}
+
+		if cached, getErr := h.idempotencyStore.Get(c.Request.Context(), uuidClaims.UUID, createScheduleEndpoint, idempotencyKey); getErr == nil {
+			if cached.Fingerprint != fingerprint {
+				status, _code := http.StatusUnprocessableEntity, code.IdempotencyKeyConflict
+				msg := "Idempotency-Key has already been used with a different request body"
+				c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+				entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Info()
+				topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
+				topSpan.SetTag("status", status).SetTag("code", _code).Finish()
+				return
+			}
+
+			var replayBody gin.H
+			_ = json.Unmarshal(cached.Body, &replayBody)
+			c.JSON(cached.Status, replayBody)
+			entry.WithFields(logrus.Fields{"status": cached.Status, "message": "replayed cached idempotent response", "request": string(reqBytes)}).Info()
+			topSpan.SetTag("status", cached.Status).SetTag("idempotency.replayed", true).Finish()
+			return
+		}
+
+		preferAsync := isPreferAsync(c)
+		coalesceKey := idempotency.Key(uuidClaims.UUID, createScheduleEndpoint, idempotencyKey)
+		executed := false
+		result, _, _ := h.idempotencyCoalescer.Do(coalesceKey, func() (*idempotency.Entry, error) {
+			executed = true
+			outcome := h.createSchedule(c, topSpan, entry, reqID, uuidClaims, receivedReq, reqBytes, preferAsync)
+			body, _ := json.Marshal(outcome.body())
+			entryToCache := &idempotency.Entry{Fingerprint: fingerprint, Status: outcome.Status, Body: body, Retryable: outcome.Retryable, CreatedAt: time.Now()}
+			// A retryable outcome (breaker-open, bulkhead-full, no available
+			// node, a downstream timeout or resource exhaustion) must
+			// not be cached - a later retry with the same Idempotency-Key
+			// needs to actually re-attempt the call, not replay the same
+			// failure for the rest of the TTL.
+			if !outcome.Retryable {
+				_ = h.idempotencyStore.Put(c.Request.Context(), uuidClaims.UUID, createScheduleEndpoint, idempotencyKey, entryToCache, h.IdempotencyCfg.TTL)
+			}
+			return entryToCache, nil
+		})
+
+		var replayBody gin.H
+		_ = json.Unmarshal(result.Body, &replayBody)
+		c.JSON(result.Status, replayBody)
+		// h.createSchedule (or enqueueAsync) already finishes this exact
+		// topSpan internally whenever this call is the one that actually ran
+		// the closure; finishing it again here would double-Finish the same
+		// jaeger span. Only a coalesced caller, whose closure never ran and
+		// whose topSpan was therefore never touched, needs it finished here.
+		if !executed {
+			topSpan.SetTag("status", result.Status).SetTag("idempotency.coalesced", true).Finish()
+		}
+		return
+	}
+
+	outcome := h.createSchedule(c, topSpan, entry, reqID, uuidClaims, receivedReq, reqBytes, isPreferAsync(c))
+	c.JSON(outcome.Status, outcome.body())
+}
+
+// isPreferAsync reports whether the client opted into async dispatch via
+// `Prefer: respond-async`, per RFC 7240.
+func isPreferAsync(c *gin.Context) bool {
+	return c.GetHeader("Prefer") == "respond-async"
+}
+
+// createSchedule runs the consul node selection, circuit breaker and
+// downstream gRPC call for a validated CreateSchedule request, logging and
+// tagging topSpan exactly as CreateSchedule used to do inline. It is
+// factored out so its outcome can be cached and replayed for a coalesced
+// Idempotency-Key retry, which owns a different *gin.Context than the one
+// that actually executed the request.
+//
+// When preferAsync is set and the downstream node is unreachable or its
+// breaker is open, the request is durably persisted to the outbox and
+// answered with 202 Accepted instead of the usual synchronous error.
+func (h *_default) createSchedule(c *gin.Context, topSpan opentracing.Span, entry *logrus.Entry, reqID string, uuidClaims jwtutil.UUIDClaims, receivedReq entity.CreateScheduleRequest, reqBytes []byte, preferAsync bool) createScheduleOutcome {
 	consulSpan := h.tracer.StartSpan("GetNextServiceNode", opentracing.ChildOf(topSpan.Context()))
 	selectedNode, err := h.consulAgent.GetNextServiceNode(topic.ScheduleServiceName)
 	if err == nil { consulSpan.SetTag("X-Request-Id", reqID).LogFields(log.Object("SelectedNode", *selectedNode)) }
@@ -73,21 +209,16 @@ func (h *_default) CreateSchedule(c *gin.Context) {
 	case nil:
 		break
 	case agenterrors.AvailableNodeNotExist:
-		msg := "available schedule service node is not exist in consul"
-		status, _code := http.StatusServiceUnavailable, code.AvailableServiceNotExist
-		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
-		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
-		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
-		topSpan.SetTag("status", status).SetTag("code", _code).Finish()
-		return
+		if preferAsync {
+			return h.enqueueAsync(c, topSpan, entry, uuidClaims, receivedReq, reqBytes)
+		}
+		gwErr := rpcerror.New(http.StatusServiceUnavailable, code.AvailableServiceNotExist, "available schedule service node is not exist in consul", true, logrus.ErrorLevel)
+		rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+		return outcomeFromError(gwErr)
 	default:
-		msg := fmt.Sprintf("unable to get service node from consul agent, err: %s", err.Error())
-		status, _code := http.StatusInternalServerError, 0
-		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
-		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
-		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
-		topSpan.SetTag("status", status).SetTag("code", _code).Finish()
-		return
+		gwErr := rpcerror.New(http.StatusInternalServerError, code.Unknown, fmt.Sprintf("unable to get service node from consul agent, err: %s", err.Error()), false, logrus.ErrorLevel)
+		rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+		return outcomeFromError(gwErr)
 	}
 
 	h.mutex.Lock()
@@ -96,6 +227,17 @@ func (h *_default) CreateSchedule(c *gin.Context) {
 	}
 	h.mutex.Unlock()
 
+	bulkhead := h.bulkheads.Get(selectedNode.Id)
+	bulkheadSample, acquired := bulkhead.Acquire()
+	if !acquired {
+		snapshot := bulkhead.Snapshot()
+		msg := fmt.Sprintf("adaptive concurrency limit reached for node %s (limit: %.1f, inflight: %d)", selectedNode.Id, snapshot.Limit, snapshot.Inflight)
+		gwErr := rpcerror.New(http.StatusServiceUnavailable, code.BulkheadFull, msg, true, logrus.ErrorLevel)
+		topSpan.SetTag("inflight", snapshot.Inflight).SetTag("limit", snapshot.Limit)
+		rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+		return outcomeFromError(gwErr)
+	}
+
 	var rpcResp *scheduleproto.DefaultScheduleResponse
 	err = h.breakers[selectedNode.Id].Run(func() (rpcErr error) {
 		scheduleSrvSpan := h.tracer.StartSpan("CreateSchedule", opentracing.ChildOf(topSpan.Context()))
@@ -112,67 +254,99 @@ func (h *_default) CreateSchedule(c *gin.Context) {
 	})
 
 	if err == breaker.ErrBreakerOpen {
-		msg := fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String())
-		status, _code := http.StatusServiceUnavailable, code.CircuitBreakerOpen
+		// breaker.Run never invoked the closure, so no RPC happened and
+		// s.start to now isn't a real RTT sample - abort without observing it.
+		bulkhead.Abort(bulkheadSample)
 		_ = h.consulAgent.FailTTLHealth(selectedNode.Metadata["CheckID"], breaker.ErrBreakerOpen.Error())
 		time.AfterFunc(h.BreakerCfg.Timeout, func() { _ = h.consulAgent.PassTTLHealth(selectedNode.Metadata["CheckID"], "close circuit breaker") })
-		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
-		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
-		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
-		topSpan.SetTag("status", status).SetTag("code", _code).Finish()
-		return
+		if preferAsync {
+			return h.enqueueAsync(c, topSpan, entry, uuidClaims, receivedReq, reqBytes)
+		}
+		gwErr := rpcerror.BreakerOpen(fmt.Sprintf("circuit breaker is open (service id: %s, time out: %s)", selectedNode.Id, h.BreakerCfg.Timeout.String()))
+		rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+		return outcomeFromError(gwErr)
 	}
 
+	bulkhead.Release(bulkheadSample)
+	snapshot := bulkhead.Snapshot()
+	concurrency.ReportSnapshot(selectedNode.Id, snapshot)
+	topSpan.SetTag("inflight", snapshot.Inflight).SetTag("limit", snapshot.Limit).SetTag("rtt_ms", snapshot.ShortRTT.Milliseconds())
+
 	switch rpcErr := err.(type) {
 	case nil:
 		break
 	case *errors.Error:
-		var status, _code int
-		var msg string
-		switch rpcErr.Code {
-		case http.StatusRequestTimeout:
-			msg = fmt.Sprintf("request time out for CreateSchedule service, detail: %s", rpcErr.Detail)
-			status, _code = http.StatusRequestTimeout, 0
-		default:
-			msg = fmt.Sprintf("CreateSchedule returns unexpected micro error, code: %d, detail: %s", rpcErr.Code, rpcErr.Detail)
-			status, _code = http.StatusInternalServerError, 0
-		}
-		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
-		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
+		gwErr := rpcerror.FromMicroError(rpcErr)
+		rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+		return outcomeFromError(gwErr)
+	default:
+		gwErr := rpcerror.FromUnexpectedError(rpcErr)
+		rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+		return outcomeFromError(gwErr)
+	}
+
+	if rpcResp.Status == http.StatusCreated {
+		status, _code := http.StatusCreated, code.Unknown
+		msg := "succeed to create new schedule"
+		respBytes, _ := json.Marshal(gin.H{"status": status, "code": _code, "message": msg, "schedule_uuid": ""})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
 		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
 		topSpan.SetTag("status", status).SetTag("code", _code).Finish()
-		return
-	default:
+		return createScheduleOutcome{Status: status, Code: _code, Message: msg}
+	}
+
+	gwErr := rpcerror.FromResponse(rpcResp.Status, rpcResp.Code, rpcResp.Msg)
+	rpcerror.Observe(topSpan, entry, gwErr, reqBytes)
+	return outcomeFromError(gwErr)
+}
+
+// outcomeFromError adapts a rpcerror.GatewayError into the
+// createScheduleOutcome shape the rest of CreateSchedule deals in.
+func outcomeFromError(gwErr *rpcerror.GatewayError) createScheduleOutcome {
+	return createScheduleOutcome{Status: gwErr.HTTPStatus, Code: gwErr.Code, Message: gwErr.Message, Retryable: gwErr.Retryable}
+}
+
+// enqueueAsync durably persists receivedReq into the outbox and answers
+// with 202 Accepted plus a tracking UUID the client can poll or subscribe
+// to via /schedules/events, instead of the usual synchronous error for an
+// unreachable node or an open breaker.
+func (h *_default) enqueueAsync(c *gin.Context, topSpan opentracing.Span, entry *logrus.Entry, uuidClaims jwtutil.UUIDClaims, receivedReq entity.CreateScheduleRequest, reqBytes []byte) createScheduleOutcome {
+	rpcReq := receivedReq.GenerateGRPCRequest()
+	rpcReq.Uuid = uuidClaims.UUID
+	payload, err := json.Marshal(rpcReq)
+	if err != nil {
 		status, _code := http.StatusInternalServerError, 0
-		msg := fmt.Sprintf("CreateSchedule returns unexpected type of error, err: %s", rpcErr.Error())
-		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		msg := fmt.Sprintf("unable to marshal request for outbox, err: %s", err.Error())
 		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
 		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
 		topSpan.SetTag("status", status).SetTag("code", _code).Finish()
-		return
+		return createScheduleOutcome{Status: status, Code: _code, Message: msg}
 	}
 
-	switch rpcResp.Status {
-	case http.StatusCreated:
-		status, _code := http.StatusCreated, 0
-		msg := "succeed to create new schedule"
-		sendResp := gin.H{"status": status, "code": _code, "message": msg, "schedule_uuid": ""}
-		c.JSON(status, sendResp)
-		respBytes, _ := json.Marshal(sendResp)
-		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "response": string(respBytes), "request": string(reqBytes)}).Info()
+	now := time.Now()
+	outboxEntry := &outbox.Entry{
+		ID:          uuid.NewString(),
+		Endpoint:    createScheduleEndpoint,
+		UUID:        uuidClaims.UUID,
+		Payload:     payload,
+		Status:      outbox.StatusPending,
+		NextAttempt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := h.outboxStore.Put(outboxEntry); err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to persist outbox entry, err: %s", err.Error())
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg, "request": string(reqBytes)}).Error()
 		topSpan.LogFields(log.Int("status", status), log.Int("code", _code), log.String("message", msg))
 		topSpan.SetTag("status", status).SetTag("code", _code).Finish()
-	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusServiceUnavailable:
-		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
-		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Error()
-		topSpan.LogFields(log.Int("status", int(rpcResp.Status)), log.Int("code", int(rpcResp.Code)), log.String("message", rpcResp.Msg))
-		topSpan.SetTag("status", rpcResp.Status).SetTag("code", rpcResp.Code).Finish()
-	default:
-		c.JSON(int(rpcResp.Status), gin.H{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg})
-		entry.WithFields(logrus.Fields{"status": rpcResp.Status, "code": rpcResp.Code, "message": rpcResp.Msg, "request": string(reqBytes)}).Info()
-		topSpan.LogFields(log.Int("status", int(rpcResp.Status)), log.Int("code", int(rpcResp.Code)), log.String("message", rpcResp.Msg))
-		topSpan.SetTag("status", rpcResp.Status).SetTag("code", rpcResp.Code).Finish()
+		return createScheduleOutcome{Status: status, Code: _code, Message: msg}
 	}
 
-	return
+	status := http.StatusAccepted
+	msg := "accepted for async processing"
+	entry.WithFields(logrus.Fields{"status": status, "message": msg, "tracking_uuid": outboxEntry.ID, "request": string(reqBytes)}).Info()
+	topSpan.LogFields(log.Int("status", status), log.String("message", msg), log.String("tracking_uuid", outboxEntry.ID))
+	topSpan.SetTag("status", status).SetTag("async", true).Finish()
+	return createScheduleOutcome{Status: status, Message: msg, TrackingID: outboxEntry.ID}
 }
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	jwtutil "gateway/tool/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ScheduleEvents streams terminal outbox outcomes for the authenticated
+// user as Server-Sent Events, so a client that received a 202 Accepted from
+// CreateSchedule's async mode can subscribe instead of polling
+// GetScheduleOutboxStatus.
+func (h *_default) ScheduleEvents(c *gin.Context) {
+	inAdvanceEntry, ok := c.Get("RequestLogEntry")
+	entry, ok := inAdvanceEntry.(*logrus.Entry)
+	if !ok {
+		msg := "unable to get request log entry from middleware"
+		c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusInternalServerError, "code": 0, "message": msg})
+		return
+	}
+
+	var uuidClaims jwtutil.UUIDClaims
+	if ok, claims, _code, msg := h.checkIfAuthenticated(c); ok {
+		uuidClaims = claims
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": http.StatusUnauthorized, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	ctx := c.Request.Context()
+	events := h.outboxEventBroker.Subscribe()
+	defer h.outboxEventBroker.Unsubscribe(events)
+
+	c.Stream(func(w gin.ResponseWriter) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case outboxEntry, ok := <-events:
+			if !ok {
+				return false
+			}
+			if outboxEntry.UUID != uuidClaims.UUID {
+				return true
+			}
+			c.SSEvent("schedule.outbox."+string(outboxEntry.Status), outboxEntry)
+			return true
+		}
+	})
+}
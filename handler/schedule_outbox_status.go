@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	jwtutil "gateway/tool/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetScheduleOutboxStatus serves the status of a schedule that was accepted
+// for async processing, looked up by the tracking UUID CreateSchedule
+// returned in its 202 Accepted response.
+func (h *_default) GetScheduleOutboxStatus(c *gin.Context) {
+	inAdvanceEntry, ok := c.Get("RequestLogEntry")
+	entry, ok := inAdvanceEntry.(*logrus.Entry)
+	if !ok {
+		msg := "unable to get request log entry from middleware"
+		c.JSON(http.StatusInternalServerError, gin.H{"status": http.StatusInternalServerError, "code": 0, "message": msg})
+		return
+	}
+
+	var uuidClaims jwtutil.UUIDClaims
+	if ok, claims, _code, msg := h.checkIfAuthenticated(c); ok {
+		uuidClaims = claims
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": http.StatusUnauthorized, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": http.StatusUnauthorized, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	trackingID := c.Param("uuid")
+	outboxEntry, err := h.outboxStore.Get(trackingID)
+	if err != nil {
+		status, _code := http.StatusInternalServerError, 0
+		msg := fmt.Sprintf("unable to look up outbox entry, err: %s", err.Error())
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Error()
+		return
+	}
+	if outboxEntry == nil || outboxEntry.UUID != uuidClaims.UUID {
+		status, _code := http.StatusNotFound, 0
+		msg := "no tracked schedule creation found for this tracking uuid"
+		c.JSON(status, gin.H{"status": status, "code": _code, "message": msg})
+		entry.WithFields(logrus.Fields{"status": status, "code": _code, "message": msg}).Info()
+		return
+	}
+
+	status := http.StatusOK
+	c.JSON(status, gin.H{
+		"status":           status,
+		"code":             0,
+		"message":          "succeed to get outbox status",
+		"tracking_uuid":    outboxEntry.ID,
+		"outbox_status":    outboxEntry.Status,
+		"attempts":         outboxEntry.Attempts,
+		"terminal_code":    outboxEntry.TerminalCode,
+		"terminal_message": outboxEntry.TerminalMessage,
+	})
+	entry.WithFields(logrus.Fields{"status": status, "tracking_uuid": outboxEntry.ID, "outbox_status": outboxEntry.Status}).Info()
+}
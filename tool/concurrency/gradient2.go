@@ -0,0 +1,152 @@
+package concurrency
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	minLimit       = 1.0
+	maxLimit       = 1000.0
+	shortRTTWindow = 10
+	longRTTWindow  = 100
+)
+
+// Limiter is an adaptive concurrency limiter for a single downstream node,
+// implementing Netflix's gradient2 algorithm: the allowed concurrency
+// tracks how far the recent RTT (shortRTT) has drifted from the best recent
+// RTT (longRTT, a minimum that slowly decays back up), so the limiter tightens under latency spikes
+// instead of only reacting to outright errors the way the circuit breaker
+// does.
+type Limiter struct {
+	mutex     sync.Mutex
+	limit     float64
+	inflight  int
+	smoothing float64
+
+	shortRTT          time.Duration
+	shortCount        int
+	longRTT           time.Duration
+	sampleSinceUpdate int
+	updateEvery       int
+}
+
+// NewLimiter returns a Limiter that starts at initialLimit and recomputes
+// its limit every updateEvery completed RPCs, smoothing each adjustment by
+// smoothing (0, 1].
+func NewLimiter(initialLimit float64, updateEvery int, smoothing float64) *Limiter {
+	if initialLimit < minLimit {
+		initialLimit = minLimit
+	}
+	return &Limiter{
+		limit:       initialLimit,
+		smoothing:   smoothing,
+		updateEvery: updateEvery,
+	}
+}
+
+// Sample is a handle returned by Acquire, used to report the outcome of the
+// RPC it guarded.
+type Sample struct {
+	start time.Time
+}
+
+// Acquire reserves a concurrency slot. ok is false when inflight has already
+// reached the current limit, in which case the caller should reject with
+// 503/BulkheadFull before dialing the downstream node.
+func (l *Limiter) Acquire() (Sample, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if float64(l.inflight) >= l.limit {
+		return Sample{}, false
+	}
+	l.inflight++
+	return Sample{start: time.Now()}, true
+}
+
+// Release records the completion of the RPC guarded by s and folds its RTT
+// into the limiter's running estimate, recomputing the limit every
+// updateEvery samples.
+func (l *Limiter) Release(s Sample) {
+	rtt := time.Since(s.start)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.inflight--
+	l.observe(rtt)
+}
+
+// Abort releases the slot reserved by s without folding an RTT sample into
+// the limiter's estimate. Use this when the guarded call never actually
+// reached the downstream node (e.g. the breaker was open), since s.start to
+// now isn't a real RPC's RTT and would otherwise pollute shortRTT/longRTT.
+func (l *Limiter) Abort(s Sample) {
+	_ = s
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.inflight--
+}
+
+// Snapshot reports the limiter's current state for metrics/tracing.
+type Snapshot struct {
+	Limit    float64
+	Inflight int
+	ShortRTT time.Duration
+	LongRTT  time.Duration
+}
+
+func (l *Limiter) Snapshot() Snapshot {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return Snapshot{Limit: l.limit, Inflight: l.inflight, ShortRTT: l.shortRTT, LongRTT: l.longRTT}
+}
+
+// observe folds rtt into the EWMA short-term RTT and the windowed minimum
+// long-term RTT, then recomputes the limit via the gradient once
+// updateEvery samples have accumulated. Caller must hold l.mutex.
+func (l *Limiter) observe(rtt time.Duration) {
+	if l.shortCount == 0 {
+		l.shortRTT = rtt
+	} else {
+		alpha := 2.0 / float64(shortRTTWindow+1)
+		l.shortRTT = time.Duration(alpha*float64(rtt) + (1-alpha)*float64(l.shortRTT))
+	}
+	l.shortCount++
+
+	if l.longRTT == 0 || rtt < l.longRTT {
+		l.longRTT = rtt
+	} else {
+		// longRTT decays slowly back up so a historical minimum doesn't
+		// permanently pin the gradient once conditions genuinely worsen.
+		decay := 1.0 / float64(longRTTWindow)
+		l.longRTT = time.Duration((1-decay)*float64(l.longRTT) + decay*float64(rtt))
+	}
+
+	l.sampleSinceUpdate++
+	if l.sampleSinceUpdate < l.updateEvery {
+		return
+	}
+	l.sampleSinceUpdate = 0
+
+	gradient := float64(l.longRTT) / float64(l.shortRTT)
+	if gradient > 1 {
+		gradient = 1
+	}
+	// queueSize is the headroom gradient2 always grants on top of the
+	// gradient-scaled limit, so the limit can climb back up once RTT
+	// recovers instead of being monotonically non-increasing once gradient
+	// dips below 1.
+	queueSize := math.Sqrt(l.limit)
+	newLimit := l.limit*gradient + queueSize
+	l.limit = l.smoothing*newLimit + (1-l.smoothing)*l.limit
+	if l.limit < minLimit {
+		l.limit = minLimit
+	}
+	if l.limit > maxLimit {
+		l.limit = maxLimit
+	}
+}
@@ -0,0 +1,59 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterRecoversAfterRTTSpike(t *testing.T) {
+	l := NewLimiter(10, 1, 1)
+
+	observeRTT := func(rtt int64) {
+		s, ok := l.Acquire()
+		if !ok {
+			t.Fatalf("expected to acquire a slot")
+		}
+		s.start = s.start.Add(-time.Duration(rtt) * time.Millisecond)
+		l.Release(s)
+	}
+
+	for i := 0; i < 5; i++ {
+		observeRTT(10)
+	}
+	baseline := l.Snapshot().Limit
+
+	for i := 0; i < 5; i++ {
+		observeRTT(100)
+	}
+	spiked := l.Snapshot().Limit
+	if spiked >= baseline {
+		t.Fatalf("expected limit to shrink under RTT spike, baseline=%v spiked=%v", baseline, spiked)
+	}
+
+	for i := 0; i < 50; i++ {
+		observeRTT(10)
+	}
+	recovered := l.Snapshot().Limit
+	if recovered <= spiked {
+		t.Fatalf("expected limit to climb back up once RTT recovered, spiked=%v recovered=%v", spiked, recovered)
+	}
+}
+
+func TestLimiterAbortDoesNotPolluteRTT(t *testing.T) {
+	l := NewLimiter(10, 1, 1)
+	before := l.Snapshot()
+
+	s, ok := l.Acquire()
+	if !ok {
+		t.Fatalf("expected to acquire a slot")
+	}
+	l.Abort(s)
+
+	after := l.Snapshot()
+	if after.ShortRTT != before.ShortRTT || after.LongRTT != before.LongRTT {
+		t.Fatalf("expected Abort to leave RTT estimates untouched, before=%+v after=%+v", before, after)
+	}
+	if after.Inflight != 0 {
+		t.Fatalf("expected Abort to release the inflight slot, got %d", after.Inflight)
+	}
+}
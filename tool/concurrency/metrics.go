@@ -0,0 +1,31 @@
+package concurrency
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// LimitGauge reports each node's current adaptive concurrency limit.
+	LimitGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "gateway",
+		Subsystem: "bulkhead",
+		Name:      "limit",
+		Help:      "Current adaptive concurrency limit per downstream node.",
+	}, []string{"node_id"})
+
+	// InflightGauge reports each node's current in-flight request count.
+	InflightGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "gateway",
+		Subsystem: "bulkhead",
+		Name:      "inflight",
+		Help:      "Current in-flight request count per downstream node.",
+	}, []string{"node_id"})
+)
+
+func init() {
+	prometheus.MustRegister(LimitGauge, InflightGauge)
+}
+
+// ReportSnapshot publishes s for nodeID to the Prometheus gauges above.
+func ReportSnapshot(nodeID string, s Snapshot) {
+	LimitGauge.WithLabelValues(nodeID).Set(s.Limit)
+	InflightGauge.WithLabelValues(nodeID).Set(float64(s.Inflight))
+}
@@ -0,0 +1,39 @@
+package concurrency
+
+import "sync"
+
+// Registry lazily creates and caches one adaptive Limiter per downstream
+// node id, mirroring how the handler keeps one circuit breaker per node in
+// its breakers map.
+type Registry struct {
+	mutex       sync.Mutex
+	byNode      map[string]*Limiter
+	initial     float64
+	updateEvery int
+	smoothing   float64
+}
+
+// NewRegistry returns a Registry whose limiters all start at initialLimit
+// and recompute every updateEvery samples with the given smoothing factor.
+func NewRegistry(initialLimit float64, updateEvery int, smoothing float64) *Registry {
+	return &Registry{
+		byNode:      make(map[string]*Limiter),
+		initial:     initialLimit,
+		updateEvery: updateEvery,
+		smoothing:   smoothing,
+	}
+}
+
+// Get returns the Limiter for nodeID, creating one if this is the first
+// time it's seen.
+func (r *Registry) Get(nodeID string) *Limiter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	limiter, ok := r.byNode[nodeID]
+	if !ok {
+		limiter = NewLimiter(r.initial, r.updateEvery, r.smoothing)
+		r.byNode[nodeID] = limiter
+	}
+	return limiter
+}
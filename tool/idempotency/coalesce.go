@@ -0,0 +1,30 @@
+package idempotency
+
+import (
+	"golang.org/x/sync/singleflight"
+)
+
+// Coalescer collapses concurrent duplicate requests for the same
+// (uuid, endpoint, Idempotency-Key) onto a single execution of fn, so a
+// mobile client that fires the same retry twice before the first one
+// finishes only triggers one downstream gRPC call.
+type Coalescer struct {
+	group singleflight.Group
+}
+
+// NewCoalescer returns a ready-to-use Coalescer.
+func NewCoalescer() *Coalescer {
+	return &Coalescer{}
+}
+
+// Do runs fn for key if no call for that key is already in flight, otherwise
+// it blocks until the in-flight call returns and shares its result.
+func (c *Coalescer) Do(key string, fn func() (*Entry, error)) (*Entry, error, bool) {
+	v, err, shared := c.group.Do(key, func() (interface{}, error) {
+		return fn()
+	})
+	if v == nil {
+		return nil, err, shared
+	}
+	return v.(*Entry), err, shared
+}
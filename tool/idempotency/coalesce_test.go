@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalescerRunsOnceForConcurrentCallers(t *testing.T) {
+	c := NewCoalescer()
+
+	var calls int32
+	var mutex sync.Mutex
+	fn := func() (*Entry, error) {
+		mutex.Lock()
+		calls++
+		mutex.Unlock()
+		// keep the call in flight long enough for every caller to join it
+		time.Sleep(100 * time.Millisecond)
+		return &Entry{Status: 201}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _ = c.Do("same-key", fn)
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected fn to run exactly once for concurrent callers sharing a key, ran %d times", calls)
+	}
+}
+
+func TestCoalescerDoesNotShareAcrossKeys(t *testing.T) {
+	c := NewCoalescer()
+
+	entryA, _, _ := c.Do("key-a", func() (*Entry, error) { return &Entry{Status: 201}, nil })
+	entryB, _, _ := c.Do("key-b", func() (*Entry, error) { return &Entry{Status: 202}, nil })
+
+	if entryA.Status != 201 || entryB.Status != 202 {
+		t.Fatalf("expected distinct keys to run independently, got %+v and %+v", entryA, entryB)
+	}
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DefaultTTL is used when a caller does not configure an explicit TTL for
+// cached idempotent responses.
+const DefaultTTL = 24 * time.Hour
+
+// ErrNotFound is returned by a Store when no entry exists for the given key.
+var ErrNotFound = errors.New("idempotency: entry not found")
+
+// Entry is the cached outcome of a previously handled request. It is stored
+// keyed by (uuid, endpoint, Idempotency-Key) so that a retried request can be
+// replayed verbatim instead of re-executing a mutation.
+type Entry struct {
+	Fingerprint string    `json:"fingerprint"`
+	Status      int       `json:"status"`
+	Body        []byte    `json:"body"`
+	// Retryable marks an entry as the outcome of a transient failure (e.g.
+	// breaker-open, bulkhead-full, a timeout from the downstream service).
+	// Callers must not Put a Retryable entry, since caching it would make a
+	// client's later retry with the same Idempotency-Key replay the same
+	// failure forever instead of actually re-attempting the call.
+	Retryable bool      `json:"retryable"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists Entry values for an in-flight or completed idempotent
+// request. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the cached entry for the key, or ErrNotFound if absent or
+	// expired.
+	Get(ctx context.Context, uuid, endpoint, key string) (*Entry, error)
+	// Put stores the entry, overwriting any previous value, and expires it
+	// after ttl.
+	Put(ctx context.Context, uuid, endpoint, key string, entry *Entry, ttl time.Duration) error
+}
+
+// Key builds the composite key a Store indexes entries by.
+func Key(uuid, endpoint, key string) string {
+	return uuid + "|" + endpoint + "|" + key
+}
+
+// Fingerprint returns the SHA-256 digest of the canonicalized JSON encoding
+// of v, used to detect an Idempotency-Key being replayed against a different
+// request payload.
+func Fingerprint(v interface{}) (string, error) {
+	canonical, err := canonicalize(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalize re-marshals v through a map so that struct field order and
+// formatting differences between two equivalent requests don't change the
+// resulting fingerprint.
+func canonicalize(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
@@ -0,0 +1,48 @@
+package idempotency
+
+import "testing"
+
+func TestFingerprintStableAcrossFieldOrder(t *testing.T) {
+	type reqA struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	type reqB struct {
+		Age  int    `json:"age"`
+		Name string `json:"name"`
+	}
+
+	fpA, err := Fingerprint(reqA{Name: "alice", Age: 30})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fpB, err := Fingerprint(reqB{Age: 30, Name: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fpA != fpB {
+		t.Fatalf("expected equal fingerprints for equivalent bodies, got %s and %s", fpA, fpB)
+	}
+}
+
+func TestFingerprintDiffersOnValueChange(t *testing.T) {
+	type req struct {
+		Name string `json:"name"`
+	}
+
+	fpA, _ := Fingerprint(req{Name: "alice"})
+	fpB, _ := Fingerprint(req{Name: "bob"})
+
+	if fpA == fpB {
+		t.Fatalf("expected different fingerprints for different bodies, both got %s", fpA)
+	}
+}
+
+func TestKeyIncludesAllComponents(t *testing.T) {
+	k1 := Key("uuid-1", "CreateSchedule", "idem-1")
+	k2 := Key("uuid-2", "CreateSchedule", "idem-1")
+	if k1 == k2 {
+		t.Fatalf("expected keys for different uuids to differ, both got %s", k1)
+	}
+}
@@ -0,0 +1,79 @@
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process, LRU-bounded Store. It is the default store
+// for a single gateway instance; deployments running more than one instance
+// behind the load balancer should use RedisStore instead so retries land on
+// whichever instance handled the original request.
+type MemoryStore struct {
+	capacity int
+
+	mutex sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryRecord struct {
+	key     string
+	entry   *Entry
+	expires time.Time
+}
+
+// NewMemoryStore returns a MemoryStore holding at most capacity entries,
+// evicting the least recently used one once full.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, uuid, endpoint, key string) (*Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	elem, ok := s.items[Key(uuid, endpoint, key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	record := elem.Value.(*memoryRecord)
+	if time.Now().After(record.expires) {
+		s.removeElement(elem)
+		return nil, ErrNotFound
+	}
+	s.ll.MoveToFront(elem)
+	return record.entry, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, uuid, endpoint, key string, entry *Entry, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := Key(uuid, endpoint, key)
+	if elem, ok := s.items[k]; ok {
+		elem.Value.(*memoryRecord).entry = entry
+		elem.Value.(*memoryRecord).expires = time.Now().Add(ttl)
+		s.ll.MoveToFront(elem)
+		return nil
+	}
+
+	elem := s.ll.PushFront(&memoryRecord{key: k, entry: entry, expires: time.Now().Add(ttl)})
+	s.items[k] = elem
+
+	if s.capacity > 0 && s.ll.Len() > s.capacity {
+		s.removeElement(s.ll.Back())
+	}
+	return nil
+}
+
+func (s *MemoryStore) removeElement(elem *list.Element) {
+	s.ll.Remove(elem)
+	delete(s.items, elem.Value.(*memoryRecord).key)
+}
@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+	entry := &Entry{Fingerprint: "fp", Status: 201, Body: []byte(`{"ok":true}`), CreatedAt: time.Now()}
+
+	if err := s.Put(ctx, "uuid", "CreateSchedule", "key", entry, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := s.Get(ctx, "uuid", "CreateSchedule", "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != 201 || got.Retryable {
+		t.Fatalf("unexpected entry: %+v", got)
+	}
+}
+
+func TestMemoryStoreRoundTripsRetryableFlag(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+	entry := &Entry{Fingerprint: "fp", Status: 503, Body: []byte(`{}`), Retryable: true, CreatedAt: time.Now()}
+
+	if err := s.Put(ctx, "uuid", "CreateSchedule", "key", entry, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := s.Get(ctx, "uuid", "CreateSchedule", "key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Retryable {
+		t.Fatalf("expected Retryable to round-trip true, got %+v", got)
+	}
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+	entry := &Entry{Fingerprint: "fp", Status: 201, Body: []byte(`{}`), CreatedAt: time.Now()}
+
+	if err := s.Put(ctx, "uuid", "CreateSchedule", "key", entry, -time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := s.Get(ctx, "uuid", "CreateSchedule", "key"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for an expired entry, got %v", err)
+	}
+}
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryStore(2)
+	ctx := context.Background()
+
+	put := func(key string) {
+		_ = s.Put(ctx, "uuid", "CreateSchedule", key, &Entry{Status: 201, CreatedAt: time.Now()}, time.Minute)
+	}
+
+	put("a")
+	put("b")
+	// touching "a" makes "b" the least recently used
+	if _, err := s.Get(ctx, "uuid", "CreateSchedule", "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	put("c")
+
+	if _, err := s.Get(ctx, "uuid", "CreateSchedule", "b"); err != ErrNotFound {
+		t.Fatalf("expected \"b\" to have been evicted, got err %v", err)
+	}
+	if _, err := s.Get(ctx, "uuid", "CreateSchedule", "a"); err != nil {
+		t.Fatalf("expected \"a\" to survive eviction, got err %v", err)
+	}
+	if _, err := s.Get(ctx, "uuid", "CreateSchedule", "c"); err != nil {
+		t.Fatalf("expected \"c\" to survive eviction, got err %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStore is a Store backed by Redis, shared across every gateway
+// instance so a retried request is replayed no matter which instance
+// handled the original one. Concurrent duplicates are only coalesced within
+// a single instance, by Coalescer.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore wraps client, namespacing every key under prefix (e.g.
+// "idempotency:") to keep it separate from other uses of the same Redis
+// instance.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Get(ctx context.Context, uuid, endpoint, key string) (*Entry, error) {
+	raw, err := s.client.Get(ctx, s.prefix+Key(uuid, endpoint, key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *RedisStore) Put(ctx context.Context, uuid, endpoint, key string, entry *Entry, ttl time.Duration) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+Key(uuid, endpoint, key), raw, ttl).Err()
+}
@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var entriesBucket = []byte("outbox_entries")
+
+// BoltStore is the default Store, durable across gateway restarts without
+// requiring a separate database service. A deployment that needs to share
+// the outbox across instances can swap in a SQLite store behind the same
+// interface.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(entriesBucket)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Put(entry *Entry) error {
+	return s.write(entry)
+}
+
+func (s *BoltStore) Update(entry *Entry) error {
+	return s.write(entry)
+}
+
+func (s *BoltStore) write(entry *Entry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).Put([]byte(entry.ID), raw)
+	})
+}
+
+func (s *BoltStore) Get(id string) (*Entry, error) {
+	var entry Entry
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(entriesBucket).Get([]byte(id))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, &entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *BoltStore) ListPending(now time.Time, limit int) ([]*Entry, error) {
+	var due []*Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(_, raw []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return err
+			}
+			if entry.Status == StatusPending && !entry.NextAttempt.After(now) {
+				due = append(due, &entry)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
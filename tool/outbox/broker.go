@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// EventBroker consumes the outbox terminal-status topic once per gateway
+// instance and fans each decoded Entry out to every subscribed SSE
+// connection. A plain kafka.Reader with a shared GroupID would instead
+// deliver each message to exactly one subscriber, dropping it for everyone
+// else subscribed at the same time - EventBroker exists so every
+// /schedules/events connection sees every message and filters for its own
+// uuid itself.
+type EventBroker struct {
+	reader *kafka.Reader
+
+	mutex       sync.Mutex
+	subscribers map[chan *Entry]struct{}
+}
+
+// NewEventBroker returns a broker consuming topic on brokers. Call Run once
+// to start fanning out; Subscribe/Unsubscribe are safe to call concurrently
+// with Run and with each other.
+func NewEventBroker(brokers []string, topic string) *EventBroker {
+	return &EventBroker{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: brokers,
+			Topic:   topic,
+		}),
+		subscribers: make(map[chan *Entry]struct{}),
+	}
+}
+
+// Run consumes the topic until ctx is done or the reader errors, broadcasting
+// every decoded Entry to the subscribers registered at that moment. Intended
+// to be started once as a main lifecycle hook, alongside the Worker.
+func (b *EventBroker) Run(ctx context.Context) {
+	for {
+		msg, err := b.reader.ReadMessage(ctx)
+		if err != nil {
+			return
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(msg.Value, &entry); err != nil {
+			continue
+		}
+		b.broadcast(&entry)
+	}
+}
+
+func (b *EventBroker) broadcast(entry *Entry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- entry:
+		default:
+			// a slow subscriber misses this entry rather than stalling every
+			// other subscriber's delivery; it stays subscribed.
+		}
+	}
+}
+
+// Subscribe registers and returns a new buffered channel that receives every
+// Entry broadcast from here on. Callers must Unsubscribe when done.
+func (b *EventBroker) Subscribe() chan *Entry {
+	ch := make(chan *Entry, 16)
+	b.mutex.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mutex.Unlock()
+	return ch
+}
+
+// Unsubscribe deregisters ch and closes it.
+func (b *EventBroker) Unsubscribe(ch chan *Entry) {
+	b.mutex.Lock()
+	delete(b.subscribers, ch)
+	b.mutex.Unlock()
+	close(ch)
+}
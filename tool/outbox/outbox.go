@@ -0,0 +1,56 @@
+package outbox
+
+import "time"
+
+// Status is the lifecycle state of an outbox Entry.
+type Status string
+
+const (
+	// StatusPending means the entry is durably stored and waiting for the
+	// worker to replay it against the downstream service.
+	StatusPending Status = "pending"
+	// StatusInFlight means the worker has claimed the entry and is currently
+	// replaying it.
+	StatusInFlight Status = "in_flight"
+	// StatusSucceeded means the downstream call eventually succeeded.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed means the worker exhausted its retry budget without a
+	// successful downstream call.
+	StatusFailed Status = "failed"
+)
+
+// Entry is a durably persisted write that couldn't be completed
+// synchronously because the downstream service was unavailable or its
+// breaker was open. It is replayed by the worker until it reaches a
+// terminal status.
+type Entry struct {
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint"`
+	UUID     string `json:"uuid"`
+	// Payload is the GenerateGRPCRequest()-shaped request, already
+	// validated, marshaled so the worker can replay it without re-parsing
+	// the original HTTP request.
+	Payload []byte `json:"payload"`
+
+	Status      Status    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// TerminalCode/TerminalMessage are populated once Status leaves
+	// StatusPending/StatusInFlight, for the status-lookup handler and the
+	// /schedules/events SSE stream to report back to the client.
+	TerminalCode    int    `json:"terminal_code,omitempty"`
+	TerminalMessage string `json:"terminal_message,omitempty"`
+}
+
+// Store persists outbox entries. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	Put(entry *Entry) error
+	Get(id string) (*Entry, error)
+	// ListPending returns entries whose NextAttempt is due, in FIFO order.
+	ListPending(now time.Time, limit int) ([]*Entry, error)
+	Update(entry *Entry) error
+}
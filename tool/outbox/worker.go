@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Result is what a Dispatcher reports back for a replayed entry.
+type Result struct {
+	Succeeded bool
+	Code      int
+	Message   string
+	// Retryable is false when the downstream service rejected the request
+	// outright (e.g. validation failure baked into the payload) and further
+	// retries would never succeed.
+	Retryable bool
+}
+
+// Dispatcher replays a single outbox entry against the downstream service,
+// going through the same consul node selection + breaker pipeline the
+// synchronous path uses.
+type Dispatcher func(ctx context.Context, entry *Entry) Result
+
+// Publisher announces a terminal outbox outcome, e.g. to Kafka so that
+// subscribers of the /schedules/events SSE endpoint learn the final status.
+type Publisher interface {
+	Publish(ctx context.Context, entry *Entry) error
+}
+
+// BackoffConfig controls the delay between replay attempts.
+type BackoffConfig struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	MaxAttempts int
+}
+
+func (b BackoffConfig) delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 0; i < attempt; i++ {
+		d = time.Duration(float64(d) * b.Multiplier)
+		if d > b.Max {
+			return b.Max
+		}
+	}
+	return d
+}
+
+// Worker periodically replays pending outbox entries until they reach a
+// terminal status, then publishes that status and leaves the entry in the
+// store for the status-lookup handler to serve.
+type Worker struct {
+	store      Store
+	dispatch   Dispatcher
+	publisher  Publisher
+	backoff    BackoffConfig
+	pollEvery  time.Duration
+	batchSize  int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWorker returns a Worker that polls store every pollEvery for due
+// entries, replaying up to batchSize of them per poll via dispatch.
+func NewWorker(store Store, dispatch Dispatcher, publisher Publisher, backoff BackoffConfig, pollEvery time.Duration, batchSize int) *Worker {
+	return &Worker{
+		store:     store,
+		dispatch:  dispatch,
+		publisher: publisher,
+		backoff:   backoff,
+		pollEvery: pollEvery,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start runs the poll loop until Stop is called. It's intended to be
+// launched once from main as a lifecycle hook alongside the gateway server.
+func (w *Worker) Start(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.pollEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.replayDue(ctx)
+		}
+	}
+}
+
+// Stop signals the poll loop to exit and blocks until it has.
+func (w *Worker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+func (w *Worker) replayDue(ctx context.Context) {
+	due, err := w.store.ListPending(time.Now(), w.batchSize)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range due {
+		entry.Status = StatusInFlight
+		entry.Attempts++
+		entry.UpdatedAt = time.Now()
+		_ = w.store.Update(entry)
+
+		result := w.dispatch(ctx, entry)
+
+		switch {
+		case result.Succeeded:
+			entry.Status = StatusSucceeded
+			entry.TerminalCode = result.Code
+			entry.TerminalMessage = result.Message
+		case !result.Retryable || entry.Attempts >= w.backoff.MaxAttempts:
+			entry.Status = StatusFailed
+			entry.TerminalCode = result.Code
+			entry.TerminalMessage = result.Message
+		default:
+			entry.Status = StatusPending
+			entry.NextAttempt = time.Now().Add(w.backoff.delay(entry.Attempts))
+			entry.UpdatedAt = time.Now()
+			_ = w.store.Update(entry)
+			continue
+		}
+
+		entry.UpdatedAt = time.Now()
+		_ = w.store.Update(entry)
+		if w.publisher != nil {
+			_ = w.publisher.Publish(ctx, entry)
+		}
+	}
+}
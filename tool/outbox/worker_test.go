@@ -0,0 +1,28 @@
+package outbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffConfigDelay(t *testing.T) {
+	b := BackoffConfig{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2, MaxAttempts: 10}
+
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{2, 400 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},  // clamped to Max
+		{10, time.Second}, // stays clamped for larger attempts
+	}
+
+	for _, tc := range cases {
+		if got := b.delay(tc.attempt); got != tc.want {
+			t.Errorf("delay(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// maxCASAttempts bounds Incr's compare-and-swap retry loop so concurrent
+// instances losing the race repeatedly back off instead of spinning against
+// Consul as fast as the loop can go.
+const maxCASAttempts = 10
+
+// window is the JSON value stored under a distributed counter's KV key. It
+// implements a fixed window: Count is reset to zero once Started is the
+// configured window size or more in the past. Requests from the previous
+// window are not carried over, so the window does not slide.
+type window struct {
+	Started time.Time `json:"started"`
+	Count   int       `json:"count"`
+}
+
+// DistributedCounter shares a rate budget across every gateway instance by
+// keeping a fixed-window counter in Consul KV, updated via compare-and-swap
+// so concurrent instances never double count.
+type DistributedCounter struct {
+	kv         *consulapi.KV
+	prefix     string
+	windowSize time.Duration
+}
+
+// NewDistributedCounter returns a counter storing its windows under
+// prefix+"/<key>" in Consul KV.
+func NewDistributedCounter(kv *consulapi.KV, prefix string, windowSize time.Duration) *DistributedCounter {
+	return &DistributedCounter{kv: kv, prefix: prefix, windowSize: windowSize}
+}
+
+// Incr increments the counter for key and reports whether the running total
+// for the current window is still within limit. It retries the CAS, backing
+// off a little more each attempt, until it wins, the read value has already
+// moved past limit, or maxCASAttempts is exhausted, so callers under
+// contention never under- or over-count and never spin unbounded against
+// Consul.
+func (c *DistributedCounter) Incr(key string, limit int) (bool, error) {
+	kvKey := fmt.Sprintf("%s/%s", c.prefix, key)
+
+	for attempt := 0; attempt < maxCASAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt) * 5 * time.Millisecond
+			jitter := time.Duration(rand.Intn(5)) * time.Millisecond
+			time.Sleep(backoff + jitter)
+		}
+
+		pair, _, err := c.kv.Get(kvKey, nil)
+		if err != nil {
+			return false, err
+		}
+
+		now := time.Now()
+		var w window
+		var modifyIndex uint64
+		if pair == nil {
+			w = window{Started: now, Count: 0}
+		} else {
+			modifyIndex = pair.ModifyIndex
+			if err := json.Unmarshal(pair.Value, &w); err != nil {
+				return false, err
+			}
+			if now.Sub(w.Started) >= c.windowSize {
+				w = window{Started: now, Count: 0}
+			}
+		}
+
+		if w.Count >= limit {
+			return false, nil
+		}
+		w.Count++
+
+		raw, err := json.Marshal(w)
+		if err != nil {
+			return false, err
+		}
+
+		ok, _, err := c.kv.CAS(&consulapi.KVPair{Key: kvKey, Value: raw, ModifyIndex: modifyIndex}, nil)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+		// lost the race against another instance, retry against the fresh value
+	}
+
+	return false, fmt.Errorf("ratelimit: exhausted %d CAS attempts against %s", maxCASAttempts, kvKey)
+}
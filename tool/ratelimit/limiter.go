@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limiter enforces a per-user and per-IP token bucket for a single route,
+// backed locally by golang.org/x/time/rate and shared across gateway
+// instances through a Consul-backed DistributedCounter.
+type Limiter struct {
+	cfg      Config
+	byUser   *localBuckets
+	byIP     *localBuckets
+	shared   *DistributedCounter
+	endpoint string
+}
+
+// NewLimiter builds a Limiter for endpoint, enforcing cfg locally and, when
+// shared is non-nil, against the cluster-wide distributed budget too.
+func NewLimiter(endpoint string, cfg Config, shared *DistributedCounter) *Limiter {
+	return &Limiter{
+		cfg:      cfg,
+		byUser:   newLocalBuckets(cfg),
+		byIP:     newLocalBuckets(cfg),
+		shared:   shared,
+		endpoint: endpoint,
+	}
+}
+
+// Allow reports whether a request from uuid/ip may proceed. A whitelisted
+// uuid always passes. RetryAfter is only meaningful when Allowed is false.
+func (l *Limiter) Allow(uuid, ip string) Decision {
+	if l.cfg.exempt(uuid) {
+		return Decision{Allowed: true}
+	}
+
+	if !l.byUser.allow(fmt.Sprintf("user:%s", uuid)) || !l.byIP.allow(fmt.Sprintf("ip:%s", ip)) {
+		return Decision{Allowed: false, RetryAfter: time.Second}
+	}
+
+	if l.shared != nil {
+		limit := int(l.cfg.Rate * l.shared.windowSize.Seconds())
+		ok, err := l.shared.Incr(fmt.Sprintf("%s/%s", l.endpoint, uuid), limit)
+		if err != nil || !ok {
+			return Decision{Allowed: false, RetryAfter: l.shared.windowSize}
+		}
+	}
+
+	return Decision{Allowed: true}
+}
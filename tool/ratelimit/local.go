@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// localBucketCapacity bounds the number of distinct subjects (UUIDs or IPs)
+// a localBuckets tracks at once, the same way idempotency.MemoryStore bounds
+// its cache, so a public-facing route can't leak one *rate.Limiter per
+// distinct caller ever seen for the life of the process.
+const localBucketCapacity = 10000
+
+// localBuckets lazily creates and caches one golang.org/x/time/rate.Limiter
+// per subject (a UUID or an IP), so that bursts from a single caller are
+// smoothed in-process before ever touching the distributed counter. It is
+// LRU-bounded at localBucketCapacity entries.
+type localBuckets struct {
+	mutex sync.Mutex
+	cfg   Config
+	ll    *list.List
+	byKey map[string]*list.Element
+}
+
+type localBucketEntry struct {
+	key     string
+	limiter *rate.Limiter
+}
+
+func newLocalBuckets(cfg Config) *localBuckets {
+	return &localBuckets{
+		cfg:   cfg,
+		ll:    list.New(),
+		byKey: make(map[string]*list.Element),
+	}
+}
+
+func (b *localBuckets) allow(subject string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if elem, ok := b.byKey[subject]; ok {
+		b.ll.MoveToFront(elem)
+		return elem.Value.(*localBucketEntry).limiter.Allow()
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(b.cfg.Rate), b.cfg.Burst)
+	elem := b.ll.PushFront(&localBucketEntry{key: subject, limiter: limiter})
+	b.byKey[subject] = elem
+
+	if b.ll.Len() > localBucketCapacity {
+		oldest := b.ll.Back()
+		b.ll.Remove(oldest)
+		delete(b.byKey, oldest.Value.(*localBucketEntry).key)
+	}
+
+	return limiter.Allow()
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLocalBucketsAllowsWithinBurst(t *testing.T) {
+	b := newLocalBuckets(Config{Rate: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if !b.allow("user:u1") {
+			t.Fatalf("expected request %d within burst to be allowed", i)
+		}
+	}
+	if b.allow("user:u1") {
+		t.Fatalf("expected request beyond burst to be denied")
+	}
+}
+
+func TestLocalBucketsTracksSubjectsIndependently(t *testing.T) {
+	b := newLocalBuckets(Config{Rate: 1, Burst: 1})
+
+	if !b.allow("user:u1") {
+		t.Fatalf("expected first request for u1 to be allowed")
+	}
+	if b.allow("user:u1") {
+		t.Fatalf("expected second request for u1 to be denied")
+	}
+	if !b.allow("user:u2") {
+		t.Fatalf("expected u2's own bucket to be unaffected by u1's burst")
+	}
+}
+
+func TestLocalBucketsEvictsLeastRecentlyUsedPastCapacity(t *testing.T) {
+	b := newLocalBuckets(Config{Rate: 1, Burst: 1})
+
+	for i := 0; i < localBucketCapacity; i++ {
+		b.allow(fmt.Sprintf("user:%d", i))
+	}
+	if b.ll.Len() != localBucketCapacity {
+		t.Fatalf("expected %d tracked subjects, got %d", localBucketCapacity, b.ll.Len())
+	}
+
+	// one more distinct subject should evict "user:0" rather than grow
+	// unbounded.
+	b.allow("user:overflow")
+	if b.ll.Len() != localBucketCapacity {
+		t.Fatalf("expected tracked subjects to stay capped at %d, got %d", localBucketCapacity, b.ll.Len())
+	}
+	if _, ok := b.byKey["user:0"]; ok {
+		t.Fatalf("expected the least recently used subject to have been evicted")
+	}
+	if _, ok := b.byKey["user:overflow"]; !ok {
+		t.Fatalf("expected the newest subject to be tracked")
+	}
+}
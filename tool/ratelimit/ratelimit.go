@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// Config describes the token-bucket budget for a single route. It is
+// populated from the gateway config file, one entry per endpoint.
+type Config struct {
+	// Rate is the number of requests allowed per second, per subject
+	// (UUID or IP).
+	Rate float64
+	// Burst is the maximum number of requests a subject may send instantly
+	// before the Rate starts throttling it.
+	Burst int
+	// Whitelist holds UUIDs that bypass the limiter entirely, e.g. internal
+	// service accounts.
+	Whitelist map[string]bool
+}
+
+// exempt returns whether uuid is exempt from limiting under cfg.
+func (cfg Config) exempt(uuid string) bool {
+	return cfg.Whitelist != nil && cfg.Whitelist[uuid]
+}
+
+// Decision is the outcome of a rate limit check.
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
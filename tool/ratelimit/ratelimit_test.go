@@ -0,0 +1,21 @@
+package ratelimit
+
+import "testing"
+
+func TestConfigExempt(t *testing.T) {
+	cfg := Config{Whitelist: map[string]bool{"service-account-1": true}}
+
+	if !cfg.exempt("service-account-1") {
+		t.Fatalf("expected whitelisted uuid to be exempt")
+	}
+	if cfg.exempt("some-other-uuid") {
+		t.Fatalf("expected non-whitelisted uuid to not be exempt")
+	}
+}
+
+func TestConfigExemptWithNilWhitelist(t *testing.T) {
+	var cfg Config
+	if cfg.exempt("anything") {
+		t.Fatalf("expected a nil whitelist to exempt nobody")
+	}
+}
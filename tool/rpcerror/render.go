@@ -0,0 +1,23 @@
+package rpcerror
+
+import (
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Observe logs gwErr with the usual status/code/message/request fields at
+// gwErr.Level and finishes span. It never writes the JSON response itself:
+// the CreateSchedule handler computes a createScheduleOutcome value from the
+// GatewayError and defers the actual c.JSON call to its caller, since that outcome may need to be cached and replayed verbatim for
+// a different *gin.Context on an Idempotency-Key retry.
+func Observe(span opentracing.Span, entry *logrus.Entry, gwErr *GatewayError, reqBytes []byte) {
+	fields := logrus.Fields{"status": gwErr.HTTPStatus, "code": gwErr.Code, "message": gwErr.Message}
+	if reqBytes != nil {
+		fields["request"] = string(reqBytes)
+	}
+	entry.WithFields(fields).Log(gwErr.Level)
+
+	span.LogFields(log.Int("status", gwErr.HTTPStatus), log.Int("code", gwErr.Code), log.String("message", gwErr.Message))
+	span.SetTag("status", gwErr.HTTPStatus).SetTag("code", gwErr.Code).Finish()
+}
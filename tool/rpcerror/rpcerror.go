@@ -0,0 +1,114 @@
+package rpcerror
+
+import (
+	"fmt"
+	"net/http"
+
+	code "gateway/utils/code/golang"
+
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// GatewayError is the canonical shape every downstream failure gets mapped
+// into: an HTTP status and symbolic code to send the client, a message to
+// both send and log, whether the caller could usefully retry, and the
+// logrus level the occurrence should be logged at. It replaces the
+// status/code/msg switch that used to be copy-pasted into every branch of
+// every write handler.
+type GatewayError struct {
+	HTTPStatus int
+	Code       int
+	Message    string
+	Retryable  bool
+	Level      logrus.Level
+}
+
+func (e *GatewayError) Error() string { return e.Message }
+
+// New builds a GatewayError directly, for failures that don't come from a
+// micro client call or a downstream response (e.g. consul node selection).
+func New(httpStatus, _code int, msg string, retryable bool, level logrus.Level) *GatewayError {
+	return &GatewayError{HTTPStatus: httpStatus, Code: _code, Message: msg, Retryable: retryable, Level: level}
+}
+
+// microTranslation is how a single *errors.Error code is rendered.
+type microTranslation struct {
+	httpStatus int
+	code       int
+	retryable  bool
+	level      logrus.Level
+	message    func(detail string) string
+}
+
+// microCodeTable maps the micro/gRPC status codes the handlers have had to
+// deal with onto a GatewayError. Codes not present here fall back to a
+// generic Unknown/500 in FromMicroError.
+var microCodeTable = map[int32]microTranslation{
+	http.StatusRequestTimeout: {
+		httpStatus: http.StatusRequestTimeout,
+		code:       code.RequestTimeout,
+		retryable:  true,
+		level:      logrus.ErrorLevel,
+		message:    func(detail string) string { return fmt.Sprintf("request time out for service call, detail: %s", detail) },
+	},
+	http.StatusGatewayTimeout: {
+		httpStatus: http.StatusGatewayTimeout,
+		code:       code.DeadlineExceeded,
+		retryable:  true,
+		level:      logrus.ErrorLevel,
+		message:    func(detail string) string { return fmt.Sprintf("downstream call exceeded its deadline, detail: %s", detail) },
+	},
+	http.StatusTooManyRequests: {
+		httpStatus: http.StatusTooManyRequests,
+		code:       code.ResourceExhausted,
+		retryable:  true,
+		level:      logrus.WarnLevel,
+		message:    func(detail string) string { return fmt.Sprintf("downstream service is resource exhausted, detail: %s", detail) },
+	},
+	http.StatusUnauthorized: {
+		httpStatus: http.StatusUnauthorized,
+		code:       code.Unauthenticated,
+		retryable:  false,
+		level:      logrus.ErrorLevel,
+		message:    func(detail string) string { return fmt.Sprintf("downstream call was unauthenticated, detail: %s", detail) },
+	},
+}
+
+// FromMicroError maps a *errors.Error returned by a go-micro client call
+// into a GatewayError.
+func FromMicroError(rpcErr *errors.Error) *GatewayError {
+	translation, ok := microCodeTable[rpcErr.Code]
+	if !ok {
+		return New(http.StatusInternalServerError, code.Unknown,
+			fmt.Sprintf("unexpected micro error, code: %d, detail: %s", rpcErr.Code, rpcErr.Detail),
+			false, logrus.ErrorLevel)
+	}
+	return New(translation.httpStatus, translation.code, translation.message(rpcErr.Detail), translation.retryable, translation.level)
+}
+
+// FromUnexpectedError maps an error of a type the gRPC client layer isn't
+// expected to return (i.e. anything other than *errors.Error or nil).
+func FromUnexpectedError(err error) *GatewayError {
+	return New(http.StatusInternalServerError, code.Unknown,
+		fmt.Sprintf("service call returned unexpected type of error, err: %s", err.Error()),
+		false, logrus.ErrorLevel)
+}
+
+// FromResponse maps the Status/Code/Msg a downstream *scheduleproto response
+// already carries. Statuses outside the handled set are passed through as
+// given, same as the handlers used to do in their default switch case.
+func FromResponse(status, respCode int32, msg string) *GatewayError {
+	level := logrus.InfoLevel
+	switch status {
+	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout, http.StatusTooManyRequests, http.StatusUnauthorized:
+		level = logrus.ErrorLevel
+	}
+	return New(int(status), int(respCode), msg, false, level)
+}
+
+// BreakerOpen maps the existing breaker.ErrBreakerOpen case.
+func BreakerOpen(msg string) *GatewayError {
+	return New(http.StatusServiceUnavailable, code.CircuitBreakerOpen, msg, true, logrus.ErrorLevel)
+}
@@ -0,0 +1,58 @@
+package rpcerror
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/micro/go-micro/v2/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFromMicroErrorKnownCode(t *testing.T) {
+	rpcErr := &errors.Error{Code: http.StatusUnauthorized, Detail: "bad token"}
+	gwErr := FromMicroError(rpcErr)
+
+	if gwErr.HTTPStatus != http.StatusUnauthorized {
+		t.Fatalf("expected HTTPStatus %d, got %d", http.StatusUnauthorized, gwErr.HTTPStatus)
+	}
+	if gwErr.Retryable {
+		t.Fatalf("expected an auth failure to not be retryable")
+	}
+}
+
+func TestFromMicroErrorRetryableCodes(t *testing.T) {
+	for _, status := range []int32{http.StatusRequestTimeout, http.StatusGatewayTimeout, http.StatusTooManyRequests} {
+		gwErr := FromMicroError(&errors.Error{Code: status, Detail: "detail"})
+		if !gwErr.Retryable {
+			t.Fatalf("expected micro status %d to map to a retryable GatewayError", status)
+		}
+	}
+}
+
+func TestFromMicroErrorUnknownCodeFallsBack(t *testing.T) {
+	gwErr := FromMicroError(&errors.Error{Code: 9999, Detail: "mystery"})
+
+	if gwErr.HTTPStatus != http.StatusInternalServerError || gwErr.Retryable {
+		t.Fatalf("expected an unmapped code to fall back to a non-retryable 500, got %+v", gwErr)
+	}
+}
+
+func TestBreakerOpenIsRetryable(t *testing.T) {
+	gwErr := BreakerOpen("circuit breaker is open")
+	if !gwErr.Retryable {
+		t.Fatalf("expected BreakerOpen to produce a retryable GatewayError")
+	}
+	if gwErr.HTTPStatus != http.StatusServiceUnavailable {
+		t.Fatalf("expected HTTPStatus %d, got %d", http.StatusServiceUnavailable, gwErr.HTTPStatus)
+	}
+}
+
+func TestFromUnexpectedErrorIsNotRetryable(t *testing.T) {
+	gwErr := FromUnexpectedError(errors.New("500", "boom", 500))
+	if gwErr.Retryable {
+		t.Fatalf("expected an unexpected error to be treated as non-retryable")
+	}
+	if gwErr.Level != logrus.ErrorLevel {
+		t.Fatalf("expected ErrorLevel, got %v", gwErr.Level)
+	}
+}
@@ -0,0 +1,6 @@
+package golang
+
+// BulkheadFull is returned when a downstream node's adaptive concurrency
+// limit has already been reached and the request is rejected before ever
+// dialing that node.
+const BulkheadFull = 5030
@@ -0,0 +1,6 @@
+package golang
+
+// IdempotencyKeyConflict is returned when an Idempotency-Key header is
+// replayed with a request payload whose fingerprint doesn't match the one
+// the key was first used with.
+const IdempotencyKeyConflict = 4220
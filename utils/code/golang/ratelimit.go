@@ -0,0 +1,5 @@
+package golang
+
+// RateLimitExceeded is returned when a caller has exceeded its per-user or
+// per-IP token bucket budget for the route.
+const RateLimitExceeded = 4290
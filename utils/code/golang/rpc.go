@@ -0,0 +1,21 @@
+package golang
+
+// Unknown is the symbolic name for the internal/unclassified error code the
+// handlers used to pass as a literal 0.
+const Unknown = 0
+
+const (
+	// RequestTimeout mirrors a downstream 408, e.g. a slow schedule-service
+	// call that never answered within its deadline.
+	RequestTimeout = 4080
+	// Unauthenticated mirrors a downstream 401, e.g. an expired or invalid
+	// service-to-service credential.
+	Unauthenticated = 4010
+	// ResourceExhausted mirrors a downstream 429, e.g. the schedule-service
+	// itself shedding load.
+	ResourceExhausted = 4291
+	// DeadlineExceeded mirrors a downstream 504, distinct from the gateway's
+	// own RequestTimeout since it means the deadline was exceeded on the
+	// schedule-service side of the call.
+	DeadlineExceeded = 5040
+)